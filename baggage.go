@@ -0,0 +1,77 @@
+// (c) Copyright IBM Corp. 2021
+// (c) Copyright Instana Inc. 2021
+
+package instana
+
+import "unicode/utf8"
+
+// maxBaggageBytes is the default upper bound on the combined key+value size of a
+// SpanContext's baggage. It protects downstream services from unbounded baggage
+// growth as a trace fans out across a call chain.
+const maxBaggageBytes = 8192
+
+// BaggageRestrictionManager is consulted by SpanContext.WithBaggageItem before a
+// baggage item is stored, allowing it to be rejected or truncated.
+type BaggageRestrictionManager interface {
+	// Restrict validates key/value, returning the (possibly truncated) value to
+	// store and whether the item is allowed at all. A hook point for a future
+	// remote-configured restriction manager.
+	Restrict(key, value string) (value2 string, allowed bool)
+}
+
+// DefaultBaggageRestrictionManager allows any baggage item without modification
+type DefaultBaggageRestrictionManager struct{}
+
+// Restrict implements BaggageRestrictionManager
+func (DefaultBaggageRestrictionManager) Restrict(key, value string) (string, bool) {
+	return value, true
+}
+
+// StaticBaggageRestrictionManager only allows a fixed whitelist of baggage keys, each
+// with its own maximum value length. Values exceeding the limit are truncated rather
+// than the item being rejected outright; keys outside the whitelist are rejected.
+type StaticBaggageRestrictionManager struct {
+	MaxValueLengths map[string]int
+}
+
+// NewStaticBaggageRestrictionManager initializes a StaticBaggageRestrictionManager
+// whitelisting keys, each restricted to maxValueLength bytes
+func NewStaticBaggageRestrictionManager(maxValueLength int, keys ...string) *StaticBaggageRestrictionManager {
+	lengths := make(map[string]int, len(keys))
+	for _, key := range keys {
+		lengths[key] = maxValueLength
+	}
+
+	return &StaticBaggageRestrictionManager{MaxValueLengths: lengths}
+}
+
+// Restrict implements BaggageRestrictionManager
+func (m *StaticBaggageRestrictionManager) Restrict(key, value string) (string, bool) {
+	maxLen, ok := m.MaxValueLengths[key]
+	if !ok {
+		return "", false
+	}
+
+	if len(value) > maxLen {
+		return truncateUTF8(value, maxLen), true
+	}
+
+	return value, true
+}
+
+// truncateUTF8 truncates s to at most maxLen bytes without splitting a multi-byte
+// rune in two, so the result stays valid UTF-8 once propagated in a header value.
+func truncateUTF8(s string, maxLen int) string {
+	for maxLen > 0 && !utf8.RuneStart(s[maxLen]) {
+		maxLen--
+	}
+
+	return s[:maxLen]
+}
+
+// baggageRestrictionManager returns the BaggageRestrictionManager configured via
+// TracerOptions.BaggageRestrictionManager, or nil if none is set, in which case
+// WithBaggageItem only enforces the total size budget.
+func baggageRestrictionManager() BaggageRestrictionManager {
+	return sensor.options.BaggageRestrictionManager
+}
@@ -0,0 +1,72 @@
+// (c) Copyright IBM Corp. 2021
+// (c) Copyright Instana Inc. 2021
+
+package instana
+
+import "testing"
+
+func TestStaticBaggageRestrictionManager_Restrict(t *testing.T) {
+	mgr := NewStaticBaggageRestrictionManager(5, "allowed")
+
+	if _, allowed := mgr.Restrict("other", "value"); allowed {
+		t.Error("a key outside the whitelist was allowed")
+	}
+
+	v, allowed := mgr.Restrict("allowed", "short")
+	if !allowed || v != "short" {
+		t.Errorf("Restrict() = (%q, %v), want (\"short\", true)", v, allowed)
+	}
+
+	v, allowed = mgr.Restrict("allowed", "toolong")
+	if !allowed || v != "toolo" {
+		t.Errorf("Restrict() = (%q, %v), want (\"toolo\", true)", v, allowed)
+	}
+}
+
+func TestStaticBaggageRestrictionManager_TruncateDoesNotSplitRunes(t *testing.T) {
+	mgr := NewStaticBaggageRestrictionManager(4, "key")
+
+	// "é" is 2 bytes in UTF-8, so truncating "aéé" (5 bytes) at 4 bytes would land mid-rune
+	v, allowed := mgr.Restrict("key", "aéé")
+	if !allowed {
+		t.Fatal("item was rejected")
+	}
+
+	if len(v) > 4 {
+		t.Fatalf("Restrict() returned %d bytes, want at most 4", len(v))
+	}
+
+	for _, r := range v {
+		if r == 0xFFFD {
+			t.Fatalf("Restrict() = %q, contains a replacement rune from a split multi-byte rune", v)
+		}
+	}
+}
+
+func TestDefaultBaggageRestrictionManager_AllowsEverything(t *testing.T) {
+	v, allowed := DefaultBaggageRestrictionManager{}.Restrict("any", "value")
+	if !allowed || v != "value" {
+		t.Errorf("Restrict() = (%q, %v), want (\"value\", true)", v, allowed)
+	}
+}
+
+func TestSpanContext_WithBaggageItem_RejectsOverBudget(t *testing.T) {
+	sc := SpanContext{}
+
+	big := make([]byte, maxBaggageBytes+1)
+	sc = sc.WithBaggageItem("key", string(big))
+
+	if len(sc.Baggage) != 0 {
+		t.Error("an item exceeding maxBaggageBytes was stored")
+	}
+}
+
+func TestSpanContext_WithBaggageItem_StoresWithinBudget(t *testing.T) {
+	sc := SpanContext{}
+
+	sc = sc.WithBaggageItem("key", "value")
+
+	if sc.Baggage["key"] != "value" {
+		t.Errorf("Baggage[key] = %q, want %q", sc.Baggage["key"], "value")
+	}
+}
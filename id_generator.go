@@ -0,0 +1,103 @@
+// (c) Copyright IBM Corp. 2021
+// (c) Copyright Instana Inc. 2021
+
+package instana
+
+import (
+	"math/rand"
+	"sync"
+	"sync/atomic"
+)
+
+// IDGenerator produces the trace and span IDs used by NewRootSpanContext and
+// NewSpanContext. Swapping the default implementation via
+// TracerOptions.IDGenerator allows deterministic IDs in tests, or encoding
+// additional information (e.g. a shard identifier) into generated IDs.
+type IDGenerator interface {
+	// NewTraceID returns the high and low 64 bits of a new trace ID
+	NewTraceID() (hi, lo int64)
+	// NewSpanID returns a new span ID
+	NewSpanID() int64
+}
+
+// DefaultIDGenerator produces random trace and span IDs, preserving the tracer's
+// original behavior, including the Gen128Bit/HighTraceIDGenerator options.
+type DefaultIDGenerator struct{}
+
+// NewTraceID implements IDGenerator
+func (DefaultIDGenerator) NewTraceID() (hi, lo int64) {
+	return highTraceID(), randomID()
+}
+
+// NewSpanID implements IDGenerator
+func (DefaultIDGenerator) NewSpanID() int64 {
+	return randomID()
+}
+
+// SeededIDGenerator produces IDs from a dedicated random source seeded with a fixed
+// value, for reproducible snapshot tests of the propagation/context code. Safe for
+// concurrent use, since the spans that drive it are typically created from multiple
+// goroutines.
+type SeededIDGenerator struct {
+	mu  sync.Mutex
+	rnd *rand.Rand
+}
+
+// NewSeededIDGenerator initializes a SeededIDGenerator whose IDs are fully
+// determined by seed
+func NewSeededIDGenerator(seed int64) *SeededIDGenerator {
+	return &SeededIDGenerator{rnd: rand.New(rand.NewSource(seed))}
+}
+
+// NewTraceID implements IDGenerator
+func (g *SeededIDGenerator) NewTraceID() (hi, lo int64) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	hi = 0
+	if sensor.options.Gen128Bit {
+		hi = g.rnd.Int63()
+	}
+
+	return hi, g.rnd.Int63()
+}
+
+// NewSpanID implements IDGenerator
+func (g *SeededIDGenerator) NewSpanID() int64 {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	return g.rnd.Int63()
+}
+
+// MonotonicIDGenerator combines a per-process monotonic counter with random bits,
+// guaranteeing IDs are unique within a process even under heavy concurrent use, and
+// letting external systems align on the counter portion of the ID.
+type MonotonicIDGenerator struct {
+	counter uint64
+}
+
+// NewTraceID implements IDGenerator
+func (g *MonotonicIDGenerator) NewTraceID() (hi, lo int64) {
+	return highTraceID(), g.nextID()
+}
+
+// NewSpanID implements IDGenerator
+func (g *MonotonicIDGenerator) NewSpanID() int64 {
+	return g.nextID()
+}
+
+func (g *MonotonicIDGenerator) nextID() int64 {
+	count := atomic.AddUint64(&g.counter, 1)
+	return int64(count)<<32 | int64(randomID()&0xffffffff)
+}
+
+// idGenerator returns the IDGenerator configured via TracerOptions.IDGenerator,
+// falling back to DefaultIDGenerator when none is set.
+func idGenerator() IDGenerator {
+	if sensor.options.IDGenerator == nil {
+		return DefaultIDGenerator{}
+	}
+
+	return sensor.options.IDGenerator
+}
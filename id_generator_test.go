@@ -0,0 +1,44 @@
+// (c) Copyright IBM Corp. 2021
+// (c) Copyright Instana Inc. 2021
+
+package instana
+
+import "testing"
+
+func TestSeededIDGenerator_Deterministic(t *testing.T) {
+	a := NewSeededIDGenerator(42)
+	b := NewSeededIDGenerator(42)
+
+	aHi, aLo := a.NewTraceID()
+	bHi, bLo := b.NewTraceID()
+
+	if aHi != bHi || aLo != bLo {
+		t.Errorf("two SeededIDGenerators with the same seed produced different trace IDs: (%d,%d) vs (%d,%d)", aHi, aLo, bHi, bLo)
+	}
+
+	if a.NewSpanID() != b.NewSpanID() {
+		t.Error("two SeededIDGenerators with the same seed produced different span IDs")
+	}
+}
+
+func TestSeededIDGenerator_DifferentSeeds(t *testing.T) {
+	a := NewSeededIDGenerator(1)
+	b := NewSeededIDGenerator(2)
+
+	if a.NewSpanID() == b.NewSpanID() {
+		t.Error("SeededIDGenerators with different seeds produced the same span ID")
+	}
+}
+
+func TestMonotonicIDGenerator_SpanIDsIncreaseMonotonically(t *testing.T) {
+	g := &MonotonicIDGenerator{}
+
+	prev := g.NewSpanID()
+	for i := 0; i < 10; i++ {
+		next := g.NewSpanID()
+		if next>>32 <= prev>>32 {
+			t.Fatalf("counter portion did not increase: prev=%#x next=%#x", prev, next)
+		}
+		prev = next
+	}
+}
@@ -0,0 +1,57 @@
+// (c) Copyright IBM Corp. 2021
+// (c) Copyright Instana Inc. 2021
+
+package instana
+
+// TracerOptions configures optional tracer behavior that the propagation, sampling
+// and ID generation helpers in this package consult. The zero value matches the
+// tracer's historical defaults.
+type TracerOptions struct {
+	// PropagationFormats lists the wire formats injectTraceContext/extractTraceContext
+	// emit and accept in addition to the native Instana headers. An empty slice falls
+	// back to PropagationInstana + PropagationW3C, matching existing deployments.
+	PropagationFormats []PropagationFormat
+
+	// Sampler is consulted by NewRootSpanContext to decide whether a new trace should
+	// be sampled. It may be reassigned at runtime, e.g. once remote sampling
+	// strategies are fetched. A nil Sampler samples every trace, matching the
+	// tracer's original behavior.
+	Sampler Sampler
+
+	// Gen128Bit enables populating the high 64 bits of new trace IDs, as required by
+	// the W3C Trace Context spec. It defaults to true; set it to false to keep
+	// generating 64-bit-only trace IDs.
+	Gen128Bit bool
+
+	// HighTraceIDGenerator optionally overrides how the high 64 bits of a 128-bit
+	// trace ID are produced, e.g. to encode an epoch-seconds timestamp into the upper
+	// bits for Elasticsearch/APM-style time-ordered IDs. It is only consulted when
+	// Gen128Bit is true; a nil value falls back to a random high half.
+	HighTraceIDGenerator func() int64
+
+	// BaggageRestrictionManager is consulted by SpanContext.WithBaggageItem before a
+	// baggage item is stored. A nil value only enforces the total size budget.
+	BaggageRestrictionManager BaggageRestrictionManager
+
+	// IDGenerator produces the trace and span IDs used by NewRootSpanContext and
+	// NewSpanContext. A nil value falls back to DefaultIDGenerator.
+	IDGenerator IDGenerator
+
+	// disableW3CTraceCorrelation turns off restoring a SpanContext from an inbound W3C
+	// trace context when no native Instana headers are present.
+	disableW3CTraceCorrelation bool
+}
+
+// DefaultOptions returns the TracerOptions used when none are supplied. It preserves
+// the tracer's original behavior except for Gen128Bit, which it turns on.
+func DefaultOptions() TracerOptions {
+	return TracerOptions{Gen128Bit: true}
+}
+
+// sensorS holds the package-level tracer state consulted by the propagation,
+// sampling and ID generation helpers in this package.
+type sensorS struct {
+	options TracerOptions
+}
+
+var sensor = &sensorS{options: DefaultOptions()}
@@ -1,6 +1,7 @@
 package instana
 
 import (
+	"io"
 	"net/http"
 	"strings"
 
@@ -18,9 +19,18 @@ const (
 	FieldL = "x-instana-l"
 	// FieldB OT Baggage header
 	FieldB = "x-instana-b-"
+	// FieldDebug requests (on inject) or signals (on extract) that a span be force-
+	// sampled regardless of the configured Sampler's decision
+	FieldDebug = "x-instana-debug"
 )
 
 func injectTraceContext(sc SpanContext, opaqueCarrier interface{}) error {
+	// ot.Binary carriers are plain io.Writer/io.Reader, unlike the TextMap carriers
+	// handled below, so they're dispatched to the binary format first.
+	if w, ok := opaqueCarrier.(io.Writer); ok {
+		return injectBinaryTraceContext(sc, w)
+	}
+
 	roCarrier, ok := opaqueCarrier.(ot.TextMapReader)
 	if !ok {
 		return ot.ErrInvalidCarrier
@@ -73,13 +83,25 @@ func injectTraceContext(sc SpanContext, opaqueCarrier interface{}) error {
 			}
 		}
 
-		addW3CTraceContext(h, sc)
+		if formatEnabled(PropagationW3C) {
+			addW3CTraceContext(h, sc)
+		}
+
+		if formatEnabled(PropagationB3) {
+			addB3TraceContext(h, sc)
+		}
 	}
 
-	carrier.Set(exstfieldT, FormatID(sc.TraceID))
+	carrier.Set(exstfieldT, formatTraceIDHeader(sc))
 	carrier.Set(exstfieldS, FormatID(sc.SpanID))
 	carrier.Set(exstfieldL, formatLevel(sc))
 
+	if sc.Debug {
+		// forward the debug request downstream so that the whole call chain is
+		// force-sampled, not just the service that received it first
+		carrier.Set(FieldDebug, "1")
+	}
+
 	for k, v := range sc.Baggage {
 		carrier.Set(exstfieldB+k, v)
 	}
@@ -88,7 +110,7 @@ func injectTraceContext(sc SpanContext, opaqueCarrier interface{}) error {
 }
 
 func addW3CTraceContext(h http.Header, sc SpanContext) {
-	traceID, spanID := FormatID(sc.TraceID), FormatID(sc.SpanID)
+	traceID, spanID := formatTraceIDHeader(sc), FormatID(sc.SpanID)
 
 	trCtx, ok := sc.ForeignParent.(w3ctrace.Context)
 	if !ok {
@@ -106,12 +128,28 @@ func addW3CTraceContext(h http.Header, sc SpanContext) {
 	p.ParentID = spanID
 
 	trCtx.RawParent = p.String()
-	trCtx.RawState = trCtx.State().Add(w3ctrace.VendorInstana, traceID+";"+spanID).String()
+
+	vendorState := traceID + ";" + spanID
+	if sc.Debug {
+		// surface the forced sampling decision to downstream Instana-aware
+		// services that only see the W3C tracestate, e.g. across a non-Instana hop
+		vendorState += ";debug=1"
+	}
+	if sc.Firehose {
+		vendorState += ";firehose=1"
+	}
+	trCtx.RawState = trCtx.State().Add(w3ctrace.VendorInstana, vendorState).String()
 
 	w3ctrace.Inject(trCtx, h)
 }
 
 func extractTraceContext(opaqueCarrier interface{}) (SpanContext, error) {
+	// ot.Binary carriers are plain io.Writer/io.Reader, unlike the TextMap carriers
+	// handled below, so they're dispatched to the binary format first.
+	if r, ok := opaqueCarrier.(io.Reader); ok {
+		return extractBinaryTraceContext(r)
+	}
+
 	spanContext := SpanContext{
 		Baggage: make(map[string]string),
 	}
@@ -127,12 +165,12 @@ func extractTraceContext(opaqueCarrier interface{}) (SpanContext, error) {
 		case FieldT:
 			fieldCount++
 
-			traceID, err := ParseID(v)
+			hi, lo, err := parseTraceIDHeader(v)
 			if err != nil {
 				return ot.ErrSpanContextCorrupted
 			}
 
-			spanContext.TraceID = traceID
+			spanContext.TraceIDHi, spanContext.TraceID = hi, lo
 		case FieldS:
 			fieldCount++
 
@@ -144,6 +182,14 @@ func extractTraceContext(opaqueCarrier interface{}) (SpanContext, error) {
 			spanContext.SpanID = spanID
 		case FieldL:
 			spanContext.Suppressed = parseLevel(v)
+			if parseDebugFlag(v) {
+				spanContext.Debug = true
+			}
+			if parseFirehoseFlag(v) {
+				spanContext.Firehose = true
+			}
+		case FieldDebug:
+			spanContext.Debug = true
 		default:
 			if strings.HasPrefix(strings.ToLower(k), FieldB) {
 				// preserve original case of the baggage key
@@ -157,7 +203,23 @@ func extractTraceContext(opaqueCarrier interface{}) (SpanContext, error) {
 		return spanContext, err
 	}
 
+	if spanContext.Debug {
+		// Force the sampling decision regardless of the order in which FieldL and
+		// FieldDebug were visited above: ForeachKey iterates a map, so either header
+		// may be seen first.
+		spanContext.Suppressed = false
+	}
+
 	if fieldCount == 0 {
+		// fall back to the B3 headers, if that format is enabled, before giving up
+		// on finding a trace context altogether
+		if c, ok := opaqueCarrier.(ot.HTTPHeadersCarrier); ok && formatEnabled(PropagationB3) {
+			if b3Ctx, ok := extractB3TraceContext(http.Header(c)); ok {
+				b3Ctx.Baggage = spanContext.Baggage
+				return b3Ctx, nil
+			}
+		}
+
 		return spanContext, ot.ErrSpanContextNotFound
 	} else if fieldCount < 2 {
 		return spanContext, ot.ErrSpanContextCorrupted
@@ -173,13 +235,57 @@ func extractTraceContext(opaqueCarrier interface{}) (SpanContext, error) {
 }
 
 func parseLevel(s string) bool {
-	return s == "0"
+	return strings.HasPrefix(s, "0")
+}
+
+// parseDebugFlag reports whether the x-instana-l value carries the ";debug=1"
+// extension used to propagate a forced sampling decision
+func parseDebugFlag(s string) bool {
+	return strings.Contains(s, "debug=1")
+}
+
+// parseFirehoseFlag reports whether the x-instana-l value carries the
+// ";firehose=1" extension used to propagate the firehose marker
+func parseFirehoseFlag(s string) bool {
+	return strings.Contains(s, "firehose=1")
+}
+
+// formatTraceIDHeader renders sc's trace ID for the x-instana-t header, including the
+// high 64 bits whenever 128-bit IDs are in use so that the full ID round-trips through
+// injectTraceContext/extractTraceContext.
+func formatTraceIDHeader(sc SpanContext) string {
+	if sc.TraceIDHi != 0 {
+		return FormatLongID(sc.TraceIDHi, sc.TraceID)
+	}
+
+	return FormatID(sc.TraceID)
+}
+
+// parseTraceIDHeader parses the value of an x-instana-t header, recognizing both the
+// plain 64-bit and the 128-bit (32 hex chars) representations.
+func parseTraceIDHeader(v string) (hi, lo int64, err error) {
+	if len(v) > 16 {
+		return ParseLongID(v)
+	}
+
+	lo, err = ParseID(v)
+
+	return 0, lo, err
 }
 
 func formatLevel(sc SpanContext) string {
+	level := "1"
 	if sc.Suppressed {
-		return "0"
+		level = "0"
+	}
+
+	if sc.Debug {
+		level += ";debug=1"
+	}
+
+	if sc.Firehose {
+		level += ";firehose=1"
 	}
 
-	return "1"
+	return level
 }
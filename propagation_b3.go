@@ -0,0 +1,172 @@
+// (c) Copyright IBM Corp. 2021
+// (c) Copyright Instana Inc. 2021
+
+package instana
+
+import (
+	"net/http"
+	"strings"
+)
+
+// PropagationFormat enumerates the wire formats that injectTraceContext/
+// extractTraceContext can emit and accept, in addition to the native Instana headers.
+type PropagationFormat uint8
+
+const (
+	// PropagationInstana is the native Instana header-based propagation format. It is
+	// always injected and extracted regardless of TracerOptions.PropagationFormats.
+	PropagationInstana PropagationFormat = iota
+	// PropagationW3C is the W3C Trace Context propagation format
+	PropagationW3C
+	// PropagationB3 is the Zipkin B3 propagation format. On inject both the
+	// multi-header and the single-header variants are emitted; on extract the
+	// single header takes precedence when present.
+	PropagationB3
+)
+
+// B3 propagation header names
+const (
+	FieldB3TraceID      = "x-b3-traceid"
+	FieldB3SpanID       = "x-b3-spanid"
+	FieldB3ParentSpanID = "x-b3-parentspanid"
+	FieldB3Sampled      = "x-b3-sampled"
+	FieldB3Flags        = "x-b3-flags"
+	FieldB3Single       = "b3"
+)
+
+func formatEnabled(f PropagationFormat) bool {
+	formats := sensor.options.PropagationFormats
+	if len(formats) == 0 {
+		// the native Instana format is always on, W3C is enabled by default for
+		// backward compatibility with existing deployments
+		return f == PropagationInstana || f == PropagationW3C
+	}
+
+	for _, pf := range formats {
+		if pf == f {
+			return true
+		}
+	}
+
+	return false
+}
+
+// addB3TraceContext injects sc into h using both the B3 multi-header and the B3
+// single-header ("b3") formats
+func addB3TraceContext(h http.Header, sc SpanContext) {
+	traceID, spanID := formatTraceIDHeader(sc), FormatID(sc.SpanID)
+
+	h.Set(FieldB3TraceID, traceID)
+	h.Set(FieldB3SpanID, spanID)
+
+	if sc.ParentID != 0 {
+		h.Set(FieldB3ParentSpanID, FormatID(sc.ParentID))
+	}
+
+	h.Set(FieldB3Sampled, b3Sampled(sc))
+
+	if sc.Debug {
+		// x-b3-flags: 1 is B3's debug flag, which implies sampled and takes
+		// precedence over x-b3-sampled downstream
+		h.Set(FieldB3Flags, "1")
+	}
+
+	h.Set(FieldB3Single, formatB3Single(sc, traceID, spanID))
+}
+
+func b3Sampled(sc SpanContext) string {
+	if sc.Suppressed {
+		return "0"
+	}
+
+	return "1"
+}
+
+func formatB3Single(sc SpanContext, traceID, spanID string) string {
+	samplingState := b3Sampled(sc)
+	if sc.Debug {
+		// "d" is B3's dedicated debug sampling state, taking precedence over 0/1
+		samplingState = "d"
+	}
+
+	s := traceID + "-" + spanID + "-" + samplingState
+	if sc.ParentID != 0 {
+		s += "-" + FormatID(sc.ParentID)
+	}
+
+	return s
+}
+
+// extractB3TraceContext attempts to restore a SpanContext from the B3 headers carried
+// by h. The single b3 header is preferred over the multi-header variant, since it
+// cannot be partially set by an intermediary.
+func extractB3TraceContext(h http.Header) (SpanContext, bool) {
+	if single := h.Get(FieldB3Single); single != "" {
+		return parseB3Single(single)
+	}
+
+	rawTraceID, rawSpanID := h.Get(FieldB3TraceID), h.Get(FieldB3SpanID)
+	if rawTraceID == "" || rawSpanID == "" {
+		return SpanContext{}, false
+	}
+
+	hi, lo, err := parseTraceIDHeader(rawTraceID)
+	if err != nil {
+		return SpanContext{}, false
+	}
+
+	spanID, err := ParseID(rawSpanID)
+	if err != nil {
+		return SpanContext{}, false
+	}
+
+	sc := SpanContext{TraceIDHi: hi, TraceID: lo, SpanID: spanID, Suppressed: h.Get(FieldB3Sampled) == "0"}
+
+	if h.Get(FieldB3Flags) == "1" {
+		sc.Debug, sc.Suppressed = true, false
+	}
+
+	if rawParentID := h.Get(FieldB3ParentSpanID); rawParentID != "" {
+		if parentID, err := ParseID(rawParentID); err == nil {
+			sc.ParentID = parentID
+		}
+	}
+
+	return sc, true
+}
+
+func parseB3Single(s string) (SpanContext, bool) {
+	parts := strings.Split(s, "-")
+	if len(parts) < 2 {
+		return SpanContext{}, false
+	}
+
+	hi, lo, err := parseTraceIDHeader(parts[0])
+	if err != nil {
+		return SpanContext{}, false
+	}
+
+	spanID, err := ParseID(parts[1])
+	if err != nil {
+		return SpanContext{}, false
+	}
+
+	sc := SpanContext{TraceIDHi: hi, TraceID: lo, SpanID: spanID}
+
+	if len(parts) > 2 {
+		switch parts[2] {
+		case "d":
+			sc.Debug = true
+		case "0":
+			sc.Suppressed = true
+		}
+	}
+
+	if len(parts) > 3 {
+		if parentID, err := ParseID(parts[3]); err == nil {
+			sc.ParentID = parentID
+		}
+	}
+
+	return sc, true
+}
@@ -0,0 +1,80 @@
+// (c) Copyright IBM Corp. 2021
+// (c) Copyright Instana Inc. 2021
+
+package instana
+
+import (
+	"net/http"
+	"testing"
+)
+
+func TestAddExtractB3TraceContext_MultiHeaderRoundTrip(t *testing.T) {
+	sc := SpanContext{TraceID: 1, SpanID: 2, ParentID: 3}
+
+	h := http.Header{}
+	addB3TraceContext(h, sc)
+
+	h.Del(FieldB3Single)
+
+	got, ok := extractB3TraceContext(h)
+	if !ok {
+		t.Fatal("extractB3TraceContext() reported not ok")
+	}
+
+	if got.TraceID != sc.TraceID || got.SpanID != sc.SpanID || got.ParentID != sc.ParentID {
+		t.Errorf("got %+v, want TraceID=%d SpanID=%d ParentID=%d", got, sc.TraceID, sc.SpanID, sc.ParentID)
+	}
+}
+
+func TestExtractB3TraceContext_SingleHeaderTakesPrecedence(t *testing.T) {
+	h := http.Header{}
+	h.Set(FieldB3Single, formatB3Single(SpanContext{TraceID: 1, SpanID: 2}, FormatID(1), FormatID(2)))
+
+	// a stale/conflicting multi-header value must be ignored once the single header
+	// is present, since it cannot be partially set by an intermediary
+	h.Set(FieldB3TraceID, FormatID(99))
+	h.Set(FieldB3SpanID, FormatID(99))
+
+	got, ok := extractB3TraceContext(h)
+	if !ok {
+		t.Fatal("extractB3TraceContext() reported not ok")
+	}
+
+	if got.TraceID != 1 || got.SpanID != 2 {
+		t.Errorf("got TraceID=%d SpanID=%d, want TraceID=1 SpanID=2", got.TraceID, got.SpanID)
+	}
+}
+
+func TestParseB3Single_SamplingStates(t *testing.T) {
+	examples := map[string]struct {
+		suppressed bool
+		debug      bool
+	}{
+		FormatID(1) + "-" + FormatID(2) + "-0": {suppressed: true},
+		FormatID(1) + "-" + FormatID(2) + "-1": {},
+		FormatID(1) + "-" + FormatID(2) + "-d": {debug: true},
+	}
+
+	for s, want := range examples {
+		got, ok := parseB3Single(s)
+		if !ok {
+			t.Errorf("parseB3Single(%q) reported not ok", s)
+			continue
+		}
+
+		if got.Suppressed != want.suppressed || got.Debug != want.debug {
+			t.Errorf("parseB3Single(%q) = %+v, want Suppressed=%v Debug=%v", s, got, want.suppressed, want.debug)
+		}
+	}
+}
+
+func TestFormatB3Single_Debug(t *testing.T) {
+	sc := SpanContext{TraceID: 1, SpanID: 2, Debug: true}
+
+	s := formatB3Single(sc, FormatID(sc.TraceID), FormatID(sc.SpanID))
+
+	want := FormatID(1) + "-" + FormatID(2) + "-d"
+	if s != want {
+		t.Errorf("formatB3Single() = %q, want %q", s, want)
+	}
+}
@@ -0,0 +1,233 @@
+// (c) Copyright IBM Corp. 2021
+// (c) Copyright Instana Inc. 2021
+
+package instana
+
+import (
+	"bufio"
+	"encoding/binary"
+	"io"
+
+	"github.com/instana/go-sensor/w3ctrace"
+	ot "github.com/opentracing/opentracing-go"
+)
+
+// binary propagation flag bits, packed into the single flags byte of the wire format
+const (
+	binaryFlagSampled    uint8 = 1 << 0
+	binaryFlagSuppressed uint8 = 1 << 1
+	binaryFlagDebug      uint8 = 1 << 2
+	binaryFlagFirehose   uint8 = 1 << 3
+)
+
+// Sanity bounds enforced on the length prefixes read off a binary carrier before
+// they are used to size an allocation or drive a loop, so a corrupted or hostile
+// carrier (this format's whole point is untrusted transports like gRPC metadata or
+// message queues) can't force an unbounded allocation.
+const (
+	// maxBinaryStringLen bounds a single length-prefixed string, matching the budget
+	// already enforced on baggage values by maxBaggageBytes.
+	maxBinaryStringLen = maxBaggageBytes
+	// maxBinaryBaggageItems bounds the baggage item count read off the wire.
+	maxBinaryBaggageItems = 1024
+)
+
+// injectBinaryTraceContext writes sc to opaqueCarrier (expected to implement
+// io.Writer, per opentracing's Binary format) using a fixed-size wire layout: 8 bytes
+// TraceIDHi, 8 bytes TraceID, 8 bytes SpanID, 8 bytes ParentID, 1 byte flags
+// (sampled|suppressed|debug|firehose), a 4-byte big-endian baggage item count, then
+// that many length-prefixed UTF-8 key/value pairs, followed by a presence byte and,
+// if set, a length-prefixed raw W3C traceparent+tracestate blob. It is invoked by the
+// tracer for the ot.Binary format, as an alternative to the hex-string header formats
+// for transports where those are wasteful (e.g. gRPC metadata, message queues).
+func injectBinaryTraceContext(sc SpanContext, opaqueCarrier interface{}) error {
+	w, ok := opaqueCarrier.(io.Writer)
+	if !ok {
+		return ot.ErrInvalidCarrier
+	}
+
+	bw := bufio.NewWriter(w)
+
+	for _, id := range [...]int64{sc.TraceIDHi, sc.TraceID, sc.SpanID, sc.ParentID} {
+		if err := binary.Write(bw, binary.BigEndian, id); err != nil {
+			return err
+		}
+	}
+
+	if err := bw.WriteByte(binaryFlags(sc)); err != nil {
+		return err
+	}
+
+	if err := binary.Write(bw, binary.BigEndian, uint32(len(sc.Baggage))); err != nil {
+		return err
+	}
+
+	for k, v := range sc.Baggage {
+		if err := writeBinaryString(bw, k); err != nil {
+			return err
+		}
+
+		if err := writeBinaryString(bw, v); err != nil {
+			return err
+		}
+	}
+
+	if err := writeW3CTraceContext(bw, sc.W3CContext); err != nil {
+		return err
+	}
+
+	return bw.Flush()
+}
+
+// writeW3CTraceContext writes a presence byte followed, if trCtx is set, by its raw
+// traceparent and tracestate as length-prefixed strings.
+func writeW3CTraceContext(w io.Writer, trCtx w3ctrace.Context) error {
+	if trCtx.IsZero() {
+		_, err := w.Write([]byte{0})
+		return err
+	}
+
+	if _, err := w.Write([]byte{1}); err != nil {
+		return err
+	}
+
+	if err := writeBinaryString(w, trCtx.RawParent); err != nil {
+		return err
+	}
+
+	return writeBinaryString(w, trCtx.RawState)
+}
+
+// extractBinaryTraceContext restores a SpanContext previously serialized by
+// injectBinaryTraceContext from opaqueCarrier (expected to implement io.Reader).
+func extractBinaryTraceContext(opaqueCarrier interface{}) (SpanContext, error) {
+	r, ok := opaqueCarrier.(io.Reader)
+	if !ok {
+		return SpanContext{}, ot.ErrInvalidCarrier
+	}
+
+	sc := SpanContext{Baggage: make(map[string]string)}
+
+	ids := make([]int64, 4)
+	for i := range ids {
+		if err := binary.Read(r, binary.BigEndian, &ids[i]); err != nil {
+			return SpanContext{}, ot.ErrSpanContextCorrupted
+		}
+	}
+	sc.TraceIDHi, sc.TraceID, sc.SpanID, sc.ParentID = ids[0], ids[1], ids[2], ids[3]
+
+	var flags uint8
+	if err := binary.Read(r, binary.BigEndian, &flags); err != nil {
+		return SpanContext{}, ot.ErrSpanContextCorrupted
+	}
+	sc.Sampled = flags&binaryFlagSampled != 0
+	sc.Suppressed = flags&binaryFlagSuppressed != 0
+	sc.Debug = flags&binaryFlagDebug != 0
+	sc.Firehose = flags&binaryFlagFirehose != 0
+
+	var baggageCount uint32
+	if err := binary.Read(r, binary.BigEndian, &baggageCount); err != nil {
+		return SpanContext{}, ot.ErrSpanContextCorrupted
+	}
+
+	if baggageCount > maxBinaryBaggageItems {
+		return SpanContext{}, ot.ErrSpanContextCorrupted
+	}
+
+	for i := uint32(0); i < baggageCount; i++ {
+		key, err := readBinaryString(r)
+		if err != nil {
+			return SpanContext{}, ot.ErrSpanContextCorrupted
+		}
+
+		value, err := readBinaryString(r)
+		if err != nil {
+			return SpanContext{}, ot.ErrSpanContextCorrupted
+		}
+
+		sc.Baggage[key] = value
+	}
+
+	trCtx, err := readW3CTraceContext(r)
+	if err != nil {
+		return SpanContext{}, err
+	}
+	sc.W3CContext = trCtx
+
+	return sc, nil
+}
+
+// readW3CTraceContext reads the presence byte and, if set, the raw traceparent and
+// tracestate written by writeW3CTraceContext.
+func readW3CTraceContext(r io.Reader) (w3ctrace.Context, error) {
+	var present [1]byte
+	if _, err := io.ReadFull(r, present[:]); err != nil {
+		return w3ctrace.Context{}, ot.ErrSpanContextCorrupted
+	}
+
+	if present[0] == 0 {
+		return w3ctrace.Context{}, nil
+	}
+
+	rawParent, err := readBinaryString(r)
+	if err != nil {
+		return w3ctrace.Context{}, ot.ErrSpanContextCorrupted
+	}
+
+	rawState, err := readBinaryString(r)
+	if err != nil {
+		return w3ctrace.Context{}, ot.ErrSpanContextCorrupted
+	}
+
+	return w3ctrace.Context{RawParent: rawParent, RawState: rawState}, nil
+}
+
+func binaryFlags(sc SpanContext) uint8 {
+	var flags uint8
+
+	if sc.Sampled {
+		flags |= binaryFlagSampled
+	}
+
+	if sc.Suppressed {
+		flags |= binaryFlagSuppressed
+	}
+
+	if sc.Debug {
+		flags |= binaryFlagDebug
+	}
+
+	if sc.Firehose {
+		flags |= binaryFlagFirehose
+	}
+
+	return flags
+}
+
+func writeBinaryString(w io.Writer, s string) error {
+	if err := binary.Write(w, binary.BigEndian, uint32(len(s))); err != nil {
+		return err
+	}
+
+	_, err := io.WriteString(w, s)
+
+	return err
+}
+
+func readBinaryString(r io.Reader) (string, error) {
+	var n uint32
+	if err := binary.Read(r, binary.BigEndian, &n); err != nil {
+		return "", err
+	}
+
+	if n > maxBinaryStringLen {
+		return "", ot.ErrSpanContextCorrupted
+	}
+
+	buf := make([]byte, n)
+	if _, err := io.ReadFull(r, buf); err != nil {
+		return "", err
+	}
+
+	return string(buf), nil
+}
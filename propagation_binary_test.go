@@ -0,0 +1,80 @@
+// (c) Copyright IBM Corp. 2021
+// (c) Copyright Instana Inc. 2021
+
+package instana
+
+import (
+	"bytes"
+	"encoding/binary"
+	"testing"
+
+	ot "github.com/opentracing/opentracing-go"
+)
+
+func TestInjectExtractBinaryTraceContext_RoundTrip(t *testing.T) {
+	sc := SpanContext{
+		TraceIDHi: 1,
+		TraceID:   2,
+		SpanID:    3,
+		ParentID:  4,
+		Sampled:   true,
+		Debug:     true,
+		Firehose:  true,
+		Baggage:   map[string]string{"foo": "bar"},
+	}
+
+	var buf bytes.Buffer
+
+	if err := injectBinaryTraceContext(sc, &buf); err != nil {
+		t.Fatalf("injectBinaryTraceContext() failed: %s", err)
+	}
+
+	got, err := extractBinaryTraceContext(&buf)
+	if err != nil {
+		t.Fatalf("extractBinaryTraceContext() failed: %s", err)
+	}
+
+	if got.TraceIDHi != sc.TraceIDHi || got.TraceID != sc.TraceID || got.SpanID != sc.SpanID || got.ParentID != sc.ParentID {
+		t.Errorf("IDs did not round-trip: got %+v, want %+v", got, sc)
+	}
+
+	if got.Sampled != sc.Sampled || got.Debug != sc.Debug || got.Firehose != sc.Firehose {
+		t.Errorf("flags did not round-trip: got %+v, want %+v", got, sc)
+	}
+
+	if got.Baggage["foo"] != "bar" {
+		t.Errorf("Baggage[foo] = %q, want %q", got.Baggage["foo"], "bar")
+	}
+}
+
+func TestExtractBinaryTraceContext_RejectsInvalidCarrier(t *testing.T) {
+	if _, err := extractBinaryTraceContext(42); err != ot.ErrInvalidCarrier {
+		t.Errorf("extractBinaryTraceContext() with a non-io.Reader carrier returned %v, want ErrInvalidCarrier", err)
+	}
+}
+
+func TestExtractBinaryTraceContext_RejectsOversizedBaggageCount(t *testing.T) {
+	var buf bytes.Buffer
+
+	// 4 IDs + flags byte
+	buf.Write(make([]byte, 8*4+1))
+
+	// a baggage count far beyond maxBinaryBaggageItems
+	binary.Write(&buf, binary.BigEndian, uint32(0xFFFFFFFF))
+
+	if _, err := extractBinaryTraceContext(&buf); err != ot.ErrSpanContextCorrupted {
+		t.Errorf("extractBinaryTraceContext() with an oversized baggage count returned %v, want ErrSpanContextCorrupted", err)
+	}
+}
+
+func TestExtractBinaryTraceContext_RejectsOversizedStringLength(t *testing.T) {
+	var buf bytes.Buffer
+
+	buf.Write(make([]byte, 8*4+1))
+	binary.Write(&buf, binary.BigEndian, uint32(1))          // one baggage item
+	binary.Write(&buf, binary.BigEndian, uint32(0xFFFFFFFF)) // its key length
+
+	if _, err := extractBinaryTraceContext(&buf); err != ot.ErrSpanContextCorrupted {
+		t.Errorf("extractBinaryTraceContext() with an oversized string length returned %v, want ErrSpanContextCorrupted", err)
+	}
+}
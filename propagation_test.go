@@ -0,0 +1,172 @@
+// (c) Copyright IBM Corp. 2021
+// (c) Copyright Instana Inc. 2021
+
+package instana
+
+import (
+	"testing"
+
+	ot "github.com/opentracing/opentracing-go"
+)
+
+func TestInjectExtractTraceContext_128BitRoundTrip(t *testing.T) {
+	sc := SpanContext{
+		TraceIDHi: 0x0102030405060708,
+		TraceID:   0x1112131415161718,
+		SpanID:    42,
+		Baggage:   map[string]string{"foo": "bar"},
+	}
+
+	carrier := ot.TextMapCarrier{}
+
+	if err := injectTraceContext(sc, carrier); err != nil {
+		t.Fatalf("injectTraceContext() failed: %s", err)
+	}
+
+	got, err := extractTraceContext(carrier)
+	if err != nil {
+		t.Fatalf("extractTraceContext() failed: %s", err)
+	}
+
+	if got.TraceIDHi != sc.TraceIDHi {
+		t.Errorf("TraceIDHi = %#x, want %#x", got.TraceIDHi, sc.TraceIDHi)
+	}
+
+	if got.TraceID != sc.TraceID {
+		t.Errorf("TraceID = %#x, want %#x", got.TraceID, sc.TraceID)
+	}
+
+	if got.SpanID != sc.SpanID {
+		t.Errorf("SpanID = %d, want %d", got.SpanID, sc.SpanID)
+	}
+
+	if got.Baggage["foo"] != "bar" {
+		t.Errorf("Baggage[foo] = %q, want %q", got.Baggage["foo"], "bar")
+	}
+}
+
+func TestInjectExtractTraceContext_64BitRoundTrip(t *testing.T) {
+	sc := SpanContext{
+		TraceID: 0x1112131415161718,
+		SpanID:  42,
+		Baggage: map[string]string{},
+	}
+
+	carrier := ot.TextMapCarrier{}
+
+	if err := injectTraceContext(sc, carrier); err != nil {
+		t.Fatalf("injectTraceContext() failed: %s", err)
+	}
+
+	got, err := extractTraceContext(carrier)
+	if err != nil {
+		t.Fatalf("extractTraceContext() failed: %s", err)
+	}
+
+	if got.TraceIDHi != 0 {
+		t.Errorf("TraceIDHi = %#x, want 0", got.TraceIDHi)
+	}
+
+	if got.TraceID != sc.TraceID {
+		t.Errorf("TraceID = %#x, want %#x", got.TraceID, sc.TraceID)
+	}
+}
+
+func TestInjectExtractTraceContext_DebugFirehoseRoundTrip(t *testing.T) {
+	sc := SpanContext{
+		TraceID:  1,
+		SpanID:   2,
+		Debug:    true,
+		Firehose: true,
+		Baggage:  map[string]string{},
+	}
+
+	carrier := ot.TextMapCarrier{}
+
+	if err := injectTraceContext(sc, carrier); err != nil {
+		t.Fatalf("injectTraceContext() failed: %s", err)
+	}
+
+	got, err := extractTraceContext(carrier)
+	if err != nil {
+		t.Fatalf("extractTraceContext() failed: %s", err)
+	}
+
+	if !got.Debug {
+		t.Error("Debug did not round-trip")
+	}
+
+	if !got.Firehose {
+		t.Error("Firehose did not round-trip")
+	}
+
+	if got.Suppressed {
+		t.Error("Suppressed = true, want false: Debug must force sampling")
+	}
+}
+
+// orderedTextMapCarrier is a TextMapReader/TextMapWriter with deterministic,
+// caller-controlled key iteration order, used to exercise both possible visit
+// orders of the level and debug headers.
+type orderedTextMapCarrier struct {
+	keys   []string
+	values map[string]string
+}
+
+func (c *orderedTextMapCarrier) Set(key, val string) {
+	if _, ok := c.values[key]; !ok {
+		c.keys = append(c.keys, key)
+	}
+
+	if c.values == nil {
+		c.values = make(map[string]string)
+	}
+
+	c.values[key] = val
+}
+
+func (c *orderedTextMapCarrier) ForeachKey(handler func(key, val string) error) error {
+	for _, k := range c.keys {
+		if err := handler(k, c.values[k]); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func TestExtractTraceContext_DebugOrderIndependent(t *testing.T) {
+	base := map[string]string{
+		FieldT: FormatID(1),
+		FieldS: FormatID(2),
+	}
+
+	orders := [][]string{
+		{FieldT, FieldS, FieldL, FieldDebug},
+		{FieldT, FieldS, FieldDebug, FieldL},
+	}
+
+	for _, order := range orders {
+		carrier := &orderedTextMapCarrier{values: map[string]string{
+			FieldL:     "0", // a plain, non-debug suppressed level
+			FieldDebug: "1",
+		}}
+		for k, v := range base {
+			carrier.values[k] = v
+		}
+		carrier.keys = order
+
+		got, err := extractTraceContext(carrier)
+		if err != nil {
+			t.Fatalf("extractTraceContext() with order %v failed: %s", order, err)
+		}
+
+		if !got.Debug {
+			t.Errorf("order %v: Debug = false, want true", order)
+		}
+
+		if got.Suppressed {
+			t.Errorf("order %v: Suppressed = true, want false: Debug must force sampling regardless of header visit order", order)
+		}
+	}
+}
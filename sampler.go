@@ -0,0 +1,154 @@
+// (c) Copyright IBM Corp. 2021
+// (c) Copyright Instana Inc. 2021
+
+package instana
+
+import (
+	"math/rand"
+	"sync"
+	"time"
+)
+
+// Sampler decides whether a new trace should be sampled. It is consulted by
+// NewRootSpanContext at root-span time; the decision is then carried by
+// SpanContext.Sampled/Suppressed for the remainder of the trace rather than being
+// re-evaluated for each child span.
+type Sampler interface {
+	// IsSampled reports whether a root span for the given operation should be sampled
+	IsSampled(operationName string) bool
+}
+
+// ConstSampler is a Sampler that always returns the same decision, regardless of the
+// operation name
+type ConstSampler bool
+
+// IsSampled implements Sampler
+func (s ConstSampler) IsSampled(operationName string) bool {
+	return bool(s)
+}
+
+// ProbabilisticSampler is a Sampler that samples a random subset of traces according
+// to a fixed rate in the range [0.0, 1.0]
+type ProbabilisticSampler float64
+
+// IsSampled implements Sampler
+func (s ProbabilisticSampler) IsSampled(operationName string) bool {
+	return rand.Float64() < float64(s)
+}
+
+// RateLimitingSampler is a Sampler that admits at most perSec samples per second,
+// using a token bucket that accrues perSec tokens every second
+type RateLimitingSampler struct {
+	mu         sync.Mutex
+	balance    float64
+	maxBalance float64
+	perSec     float64
+	lastTick   time.Time
+}
+
+// NewRateLimitingSampler initializes a new RateLimitingSampler admitting up to perSec
+// samples per second
+func NewRateLimitingSampler(perSec float64) *RateLimitingSampler {
+	maxBalance := perSec
+	if maxBalance < 1 {
+		maxBalance = 1
+	}
+
+	return &RateLimitingSampler{
+		balance:    maxBalance,
+		maxBalance: maxBalance,
+		perSec:     perSec,
+		lastTick:   time.Now(),
+	}
+}
+
+// IsSampled implements Sampler
+func (s *RateLimitingSampler) IsSampled(operationName string) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := time.Now()
+	s.balance += now.Sub(s.lastTick).Seconds() * s.perSec
+	if s.balance > s.maxBalance {
+		s.balance = s.maxBalance
+	}
+	s.lastTick = now
+
+	if s.balance < 1 {
+		return false
+	}
+
+	s.balance--
+
+	return true
+}
+
+// GuaranteedThroughputSampler is a Sampler that guarantees at least lowerBoundPerSec
+// samples per second via an embedded RateLimitingSampler, and samples probabilistically
+// at the given rate above that floor
+type GuaranteedThroughputSampler struct {
+	lowerBound  *RateLimitingSampler
+	probability ProbabilisticSampler
+}
+
+// NewGuaranteedThroughputSampler initializes a new GuaranteedThroughputSampler
+func NewGuaranteedThroughputSampler(lowerBoundPerSec, probability float64) *GuaranteedThroughputSampler {
+	return &GuaranteedThroughputSampler{
+		lowerBound:  NewRateLimitingSampler(lowerBoundPerSec),
+		probability: ProbabilisticSampler(probability),
+	}
+}
+
+// IsSampled implements Sampler
+func (s *GuaranteedThroughputSampler) IsSampled(operationName string) bool {
+	if s.probability.IsSampled(operationName) {
+		// still consume from the rate limiter's budget so that it reflects the
+		// actual sampling rate once the probabilistic sampler starts contributing
+		s.lowerBound.IsSampled(operationName)
+		return true
+	}
+
+	return s.lowerBound.IsSampled(operationName)
+}
+
+// PerOperationSampler maintains an independent GuaranteedThroughputSampler per
+// operation name, each guaranteeing the same lower-bound throughput. The sampler for
+// an operation is created on its first call, so no operation is ever sampled by a
+// plain ProbabilisticSampler without the lower-bound guarantee
+type PerOperationSampler struct {
+	mu               sync.Mutex
+	samplers         map[string]*GuaranteedThroughputSampler
+	lowerBoundPerSec float64
+	defaultSampler   ProbabilisticSampler
+}
+
+// NewPerOperationSampler initializes a new PerOperationSampler. Every operation gets
+// its own GuaranteedThroughputSampler guaranteeing lowerBoundPerSec samples/sec, with
+// defaultProbability applied above that floor
+func NewPerOperationSampler(lowerBoundPerSec, defaultProbability float64) *PerOperationSampler {
+	return &PerOperationSampler{
+		samplers:         make(map[string]*GuaranteedThroughputSampler),
+		lowerBoundPerSec: lowerBoundPerSec,
+		defaultSampler:   ProbabilisticSampler(defaultProbability),
+	}
+}
+
+// IsSampled implements Sampler
+func (s *PerOperationSampler) IsSampled(operationName string) bool {
+	s.mu.Lock()
+	sampler, ok := s.samplers[operationName]
+	if !ok {
+		sampler = NewGuaranteedThroughputSampler(s.lowerBoundPerSec, float64(s.defaultSampler))
+		s.samplers[operationName] = sampler
+	}
+	s.mu.Unlock()
+
+	return sampler.IsSampled(operationName)
+}
+
+// currentSampler returns the Sampler configured via TracerOptions.Sampler, which may
+// be reloaded at runtime by assigning a new value (e.g. once remote sampling
+// strategies are fetched)
+func currentSampler() Sampler {
+	return sensor.options.Sampler
+}
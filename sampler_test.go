@@ -0,0 +1,58 @@
+// (c) Copyright IBM Corp. 2021
+// (c) Copyright Instana Inc. 2021
+
+package instana
+
+import "testing"
+
+func TestConstSampler(t *testing.T) {
+	if !ConstSampler(true).IsSampled("op") {
+		t.Error("ConstSampler(true) did not sample")
+	}
+
+	if ConstSampler(false).IsSampled("op") {
+		t.Error("ConstSampler(false) sampled")
+	}
+}
+
+func TestProbabilisticSampler(t *testing.T) {
+	if !ProbabilisticSampler(1).IsSampled("op") {
+		t.Error("ProbabilisticSampler(1) did not sample")
+	}
+
+	if ProbabilisticSampler(0).IsSampled("op") {
+		t.Error("ProbabilisticSampler(0) sampled")
+	}
+}
+
+func TestRateLimitingSampler_Burst(t *testing.T) {
+	s := NewRateLimitingSampler(1)
+
+	if !s.IsSampled("op") {
+		t.Fatal("first sample within the initial balance was rejected")
+	}
+
+	if s.IsSampled("op") {
+		t.Fatal("second immediate sample exceeded the 1/sec budget but was admitted")
+	}
+}
+
+func TestGuaranteedThroughputSampler_FloorAlwaysSamples(t *testing.T) {
+	s := NewGuaranteedThroughputSampler(1, 0)
+
+	if !s.IsSampled("op") {
+		t.Fatal("GuaranteedThroughputSampler with a 0 probability and available floor budget did not sample")
+	}
+}
+
+func TestPerOperationSampler_IndependentPerOperation(t *testing.T) {
+	s := NewPerOperationSampler(1, 0)
+
+	if !s.IsSampled("a") {
+		t.Fatal("first sample for operation \"a\" was rejected")
+	}
+
+	if !s.IsSampled("b") {
+		t.Fatal("operation \"b\" should have its own independent throughput budget")
+	}
+}
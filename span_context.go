@@ -39,6 +39,14 @@ type SpanContext struct {
 	Sampled bool
 	// Whether the trace is suppressed and should not be sent to the agent.
 	Suppressed bool
+	// Debug forces the trace to be sampled regardless of the configured Sampler's
+	// decision. It is requested by an upstream caller via the x-instana-debug header.
+	Debug bool
+	// Firehose marks a span to be excluded from aggregate service-level metrics.
+	// Unlike Debug, it does not force the span to be recorded. It is propagated as an
+	// extension to the x-instana-l level header and the W3C tracestate in= vendor
+	// entry, the same way Debug is.
+	Firehose bool
 	// The span's associated baggage.
 	Baggage map[string]string // initialized on first use
 	// The W3C trace context
@@ -49,13 +57,22 @@ type SpanContext struct {
 	Correlation EUMCorrelationData
 }
 
-// NewRootSpanContext initializes a new root span context issuing a new trace ID
-func NewRootSpanContext() SpanContext {
-	spanID := randomID()
+// NewRootSpanContext initializes a new root span context issuing a new trace ID. The
+// sampling decision for the whole trace is made here, by consulting the tracer's
+// configured Sampler, and is carried by the resulting context's Sampled/Suppressed
+// fields rather than being re-evaluated for child spans.
+func NewRootSpanContext(operationName string) SpanContext {
+	hi, lo := idGenerator().NewTraceID()
 
 	c := SpanContext{
-		TraceID: spanID,
-		SpanID:  spanID,
+		TraceIDHi: hi,
+		TraceID:   lo,
+		SpanID:    lo,
+	}
+
+	if sampler := currentSampler(); sampler != nil {
+		c.Sampled = sampler.IsSampled(operationName)
+		c.Suppressed = !c.Sampled
 	}
 
 	c.W3CContext = newW3CTraceContext(c)
@@ -63,10 +80,28 @@ func NewRootSpanContext() SpanContext {
 	return c
 }
 
+// highTraceID returns the high 64 bits of a new 128-bit trace ID, honoring
+// TracerOptions.Gen128Bit (on by default) and TracerOptions.HighTraceIDGenerator, an
+// optional override used e.g. to encode an epoch-seconds timestamp into the upper
+// bits for Elasticsearch/APM-style time-ordered IDs. When 128-bit IDs are disabled,
+// TraceIDHi stays 0, matching the tracer's original 64-bit-only behavior.
+func highTraceID() int64 {
+	if !sensor.options.Gen128Bit {
+		return 0
+	}
+
+	if gen := sensor.options.HighTraceIDGenerator; gen != nil {
+		return gen()
+	}
+
+	return randomID()
+}
+
 // NewSpanContext initializes a new child span context from its parent. It will
 // ignore the parent context if it contains neither Instana trace and span IDs
-// nor a W3C trace context
-func NewSpanContext(parent SpanContext) SpanContext {
+// nor a W3C trace context. operationName is only used if a new trace needs to be
+// started, i.e. parent turns out to be empty.
+func NewSpanContext(operationName string, parent SpanContext) SpanContext {
 	var foreignTrace bool
 	if parent.TraceIDHi == 0 && parent.TraceID == 0 && parent.SpanID == 0 {
 		parent = restoreFromW3CTraceContext(parent.W3CContext)
@@ -74,7 +109,7 @@ func NewSpanContext(parent SpanContext) SpanContext {
 	}
 
 	if parent.TraceIDHi == 0 && parent.TraceID == 0 && parent.SpanID == 0 {
-		c := NewRootSpanContext()
+		c := NewRootSpanContext(operationName)
 
 		// preserve the W3C trace context even if it was not used
 		if !parent.W3CContext.IsZero() {
@@ -85,7 +120,7 @@ func NewSpanContext(parent SpanContext) SpanContext {
 	}
 
 	c := parent.Clone()
-	c.SpanID, c.ParentID = randomID(), parent.SpanID
+	c.SpanID, c.ParentID = idGenerator().NewSpanID(), parent.SpanID
 	c.ForeignTrace = foreignTrace
 
 	// initialize W3C trace context if it's not set already
@@ -103,7 +138,7 @@ func NewSpanContext(parent SpanContext) SpanContext {
 	if foreignTrace {
 		w3cState := c.W3CContext.State()
 		if ancestor, ok := w3cState.Fetch(w3ctrace.VendorInstana); ok {
-			if ref, ok := parseW3CInstanaState(ancestor); ok {
+			if ref, _, _, ok := parseW3CInstanaState(ancestor); ok {
 				c.Links = append(c.Links, ref)
 			}
 		}
@@ -133,11 +168,17 @@ func restoreFromW3CTraceContext(trCtx w3ctrace.Context) SpanContext {
 		return SpanContext{}
 	}
 
+	// the traceparent itself carries no debug/firehose bits; those only ride along
+	// in the Instana vendor entry of the tracestate, if present
+	debug, firehose := parseW3CInstanaFlags(trCtx)
+
 	return SpanContext{
 		TraceIDHi:  traceIDHi,
 		TraceID:    traceIDLo,
 		SpanID:     parentID,
-		Suppressed: !parent.Flags.Sampled,
+		Suppressed: !parent.Flags.Sampled && !debug,
+		Debug:      debug,
+		Firehose:   firehose,
 		W3CContext: trCtx,
 	}
 }
@@ -156,7 +197,7 @@ func restoreFromW3CTraceState(trCtx w3ctrace.Context) SpanContext {
 		return c
 	}
 
-	ref, ok := parseW3CInstanaState(state)
+	ref, debug, firehose, ok := parseW3CInstanaState(state)
 	if !ok {
 		return c
 	}
@@ -172,10 +213,22 @@ func restoreFromW3CTraceState(trCtx w3ctrace.Context) SpanContext {
 	}
 
 	c.TraceIDHi, c.TraceID, c.SpanID = traceIDHi, traceIDLo, parentID
+	c.Debug, c.Firehose = debug, firehose
+	if debug {
+		c.Suppressed = false
+	}
 
 	return c
 }
 
+// ShouldRecord reports whether a span carrying this context should be reported to
+// the agent. It is consulted by the recorder instead of checking Suppressed
+// directly, since Debug forces a span to be recorded even when the sampler
+// decision (or a downstream x-instana-l) suppressed it.
+func (c SpanContext) ShouldRecord() bool {
+	return !c.Suppressed || c.Debug
+}
+
 // ForeachBaggageItem belongs to the opentracing.SpanContext interface
 func (c SpanContext) ForeachBaggageItem(handler func(k, v string) bool) {
 	for k, v := range c.Baggage {
@@ -185,11 +238,27 @@ func (c SpanContext) ForeachBaggageItem(handler func(k, v string) bool) {
 	}
 }
 
-// WithBaggageItem returns an entirely new SpanContext with the
-// given key:value baggage pair set.
+// WithBaggageItem returns an entirely new SpanContext with the given key:value
+// baggage pair set, provided it passes the configured BaggageRestrictionManager (if
+// any) and does not push the total baggage size past maxBaggageBytes. Rejected items
+// are logged and otherwise dropped, leaving the rest of the baggage untouched.
 func (c SpanContext) WithBaggageItem(key, val string) SpanContext {
 	res := c.Clone()
 
+	if mgr := baggageRestrictionManager(); mgr != nil {
+		v, allowed := mgr.Restrict(key, val)
+		if !allowed {
+			log.Warn("baggage item rejected by BaggageRestrictionManager: ", key)
+			return res
+		}
+		val = v
+	}
+
+	if res.baggageSize()+len(key)+len(val) > maxBaggageBytes {
+		log.Warn("baggage item rejected: would exceed the ", maxBaggageBytes, "-byte budget: ", key)
+		return res
+	}
+
 	if res.Baggage == nil {
 		res.Baggage = make(map[string]string, 1)
 	}
@@ -198,6 +267,16 @@ func (c SpanContext) WithBaggageItem(key, val string) SpanContext {
 	return res
 }
 
+// baggageSize returns the combined size in bytes of the baggage's keys and values
+func (c SpanContext) baggageSize() int {
+	var size int
+	for k, v := range c.Baggage {
+		size += len(k) + len(v)
+	}
+
+	return size
+}
+
 // Clone returns a deep copy of a SpanContext
 func (c SpanContext) Clone() SpanContext {
 	res := SpanContext{
@@ -207,6 +286,8 @@ func (c SpanContext) Clone() SpanContext {
 		ParentID:   c.ParentID,
 		Sampled:    c.Sampled,
 		Suppressed: c.Suppressed,
+		Debug:      c.Debug,
+		Firehose:   c.Firehose,
 		W3CContext: c.W3CContext,
 	}
 
@@ -231,14 +312,38 @@ func newW3CTraceContext(c SpanContext) w3ctrace.Context {
 	})
 }
 
-func parseW3CInstanaState(vendorData string) (ancestor SpanReference, ok bool) {
-	ind := strings.IndexByte(vendorData, ';')
-	if ind < 0 {
-		return SpanReference{}, false
+// parseW3CInstanaState parses the Instana vendor entry of a W3C tracestate header:
+// "{traceID};{spanID}", optionally followed by further ";"-separated extensions
+// (e.g. "debug=1", "firehose=1") carrying the forced sampling decision across a
+// W3C hop, separately from the ancestor reference.
+func parseW3CInstanaState(vendorData string) (ancestor SpanReference, debug, firehose, ok bool) {
+	parts := strings.SplitN(vendorData, ";", 3)
+	if len(parts) < 2 {
+		return SpanReference{}, false, false, false
+	}
+
+	ancestor = SpanReference{
+		TraceID: parts[0],
+		SpanID:  parts[1],
+	}
+
+	if len(parts) > 2 {
+		debug = strings.Contains(parts[2], "debug=1")
+		firehose = strings.Contains(parts[2], "firehose=1")
+	}
+
+	return ancestor, debug, firehose, true
+}
+
+// parseW3CInstanaFlags extracts the debug/firehose extensions from the Instana
+// vendor entry of trCtx's tracestate, if present.
+func parseW3CInstanaFlags(trCtx w3ctrace.Context) (debug, firehose bool) {
+	state, ok := trCtx.State().Fetch(w3ctrace.VendorInstana)
+	if !ok {
+		return false, false
 	}
 
-	return SpanReference{
-		TraceID: vendorData[:ind],
-		SpanID:  vendorData[ind+1:],
-	}, true
+	_, debug, firehose, _ = parseW3CInstanaState(state)
+
+	return debug, firehose
 }
@@ -0,0 +1,50 @@
+// (c) Copyright IBM Corp. 2021
+// (c) Copyright Instana Inc. 2021
+
+package instana
+
+import (
+	"testing"
+
+	"github.com/instana/go-sensor/w3ctrace"
+)
+
+func TestShouldRecord(t *testing.T) {
+	examples := map[string]struct {
+		sc   SpanContext
+		want bool
+	}{
+		"sampled":            {SpanContext{}, true},
+		"suppressed":         {SpanContext{Suppressed: true}, false},
+		"suppressed + debug": {SpanContext{Suppressed: true, Debug: true}, true},
+	}
+
+	for name, example := range examples {
+		if got := example.sc.ShouldRecord(); got != example.want {
+			t.Errorf("%s: ShouldRecord() = %v, want %v", name, got, example.want)
+		}
+	}
+}
+
+func TestRestoreFromW3CTraceState_DebugFirehose(t *testing.T) {
+	trCtx := w3ctrace.New(w3ctrace.Parent{
+		Version:  w3ctrace.Version_Max,
+		TraceID:  FormatLongID(1, 2),
+		ParentID: FormatID(3),
+	})
+	trCtx.RawState = trCtx.State().Add(w3ctrace.VendorInstana, FormatLongID(1, 2)+";"+FormatID(3)+";debug=1;firehose=1").String()
+
+	sc := restoreFromW3CTraceState(trCtx)
+
+	if !sc.Debug {
+		t.Error("Debug was not restored from the tracestate in= vendor entry")
+	}
+
+	if !sc.Firehose {
+		t.Error("Firehose was not restored from the tracestate in= vendor entry")
+	}
+
+	if sc.Suppressed {
+		t.Error("Suppressed = true, want false: Debug must force sampling")
+	}
+}